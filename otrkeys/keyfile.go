@@ -0,0 +1,132 @@
+package otrkeys
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	"github.com/twstrike/otr3"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	saltSize = 24
+	keyBytes = 32
+)
+
+// SaveKeyFile serializes priv and writes it to path as base64 text, the same
+// convention otrcat uses for on-disk libotr keys. If passphrase is
+// non-empty, the serialized key is sealed with a scrypt-derived secretbox
+// key first, and the salt is recorded in a small header above the base64
+// body so LoadKeyFile can re-derive the same key.
+func SaveKeyFile(path string, priv otr3.PrivateKey, passphrase string) error {
+	plain := priv.Serialize()
+
+	if passphrase == "" {
+		return ioutil.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(plain)+"\n"), 0600)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return err
+	}
+
+	var keyArr [keyBytes]byte
+	copy(keyArr[:], key)
+	sealed := secretbox.Seal(nil, plain, &nonce, &keyArr)
+
+	header := "# coyim otr private key\n" +
+		"# kdf: scrypt\n" +
+		"# salt: " + base64.StdEncoding.EncodeToString(salt) + "\n" +
+		"# nonce: " + base64.StdEncoding.EncodeToString(nonce[:]) + "\n"
+
+	contents := header + base64.StdEncoding.EncodeToString(sealed) + "\n"
+	return ioutil.WriteFile(path, []byte(contents), 0600)
+}
+
+// LoadKeyFile reads a file written by SaveKeyFile and parses the private key
+// it contains. passphrase is ignored for files that were saved unencrypted.
+func LoadKeyFile(path string, passphrase string) (otr3.PrivateKey, error) {
+	var priv otr3.PrivateKey
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return priv, err
+	}
+
+	var saltB64, nonceB64 string
+	var bodyLines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# salt: "):
+			saltB64 = strings.TrimPrefix(line, "# salt: ")
+		case strings.HasPrefix(line, "# nonce: "):
+			nonceB64 = strings.TrimPrefix(line, "# nonce: ")
+		case strings.HasPrefix(line, "#"):
+			// other header/comment lines are ignored
+		default:
+			bodyLines = append(bodyLines, strings.TrimSpace(line))
+		}
+	}
+
+	body, err := base64.StdEncoding.DecodeString(strings.Join(bodyLines, ""))
+	if err != nil {
+		return priv, errors.New("otrkeys: malformed key file: " + err.Error())
+	}
+
+	if saltB64 == "" {
+		if !priv.Import(body) {
+			return priv, errors.New("otrkeys: failed to parse private key")
+		}
+		return priv, nil
+	}
+
+	if passphrase == "" {
+		return priv, errors.New("otrkeys: key file is passphrase-protected but no passphrase was given")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(saltB64)
+	if err != nil {
+		return priv, errors.New("otrkeys: malformed salt: " + err.Error())
+	}
+	nonceBytes, err := base64.StdEncoding.DecodeString(nonceB64)
+	if err != nil || len(nonceBytes) != 24 {
+		return priv, errors.New("otrkeys: malformed nonce")
+	}
+	var nonce [24]byte
+	copy(nonce[:], nonceBytes)
+
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return priv, err
+	}
+	var keyArr [keyBytes]byte
+	copy(keyArr[:], key)
+
+	plain, ok := secretbox.Open(nil, body, &nonce, &keyArr)
+	if !ok {
+		return priv, errors.New("otrkeys: incorrect passphrase or corrupted key file")
+	}
+
+	if !priv.Import(plain) {
+		return priv, errors.New("otrkeys: failed to parse decrypted private key")
+	}
+	return priv, nil
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keyBytes)
+}
@@ -0,0 +1,58 @@
+package otrkeys
+
+import (
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/twstrike/otr3"
+)
+
+func testKey(t *testing.T) otr3.PrivateKey {
+	var priv otr3.PrivateKey
+	priv.Generate(rand.Reader)
+	return priv
+}
+
+func TestSaveLoadKeyFileUnencrypted(t *testing.T) {
+	priv := testKey(t)
+	path := filepath.Join(t.TempDir(), "otr.key")
+
+	if err := SaveKeyFile(path, priv, ""); err != nil {
+		t.Fatalf("SaveKeyFile: %v", err)
+	}
+
+	loaded, err := LoadKeyFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadKeyFile: %v", err)
+	}
+
+	if string(loaded.Serialize()) != string(priv.Serialize()) {
+		t.Errorf("loaded key doesn't match the saved key")
+	}
+}
+
+func TestSaveLoadKeyFileEncrypted(t *testing.T) {
+	priv := testKey(t)
+	path := filepath.Join(t.TempDir(), "otr.key")
+
+	if err := SaveKeyFile(path, priv, "correct horse battery staple"); err != nil {
+		t.Fatalf("SaveKeyFile: %v", err)
+	}
+
+	loaded, err := LoadKeyFile(path, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("LoadKeyFile: %v", err)
+	}
+	if string(loaded.Serialize()) != string(priv.Serialize()) {
+		t.Errorf("loaded key doesn't match the saved key")
+	}
+
+	if _, err := LoadKeyFile(path, "wrong passphrase"); err == nil {
+		t.Errorf("LoadKeyFile with the wrong passphrase should have failed")
+	}
+
+	if _, err := LoadKeyFile(path, ""); err == nil {
+		t.Errorf("LoadKeyFile with no passphrase should have failed for an encrypted file")
+	}
+}
@@ -0,0 +1,92 @@
+// Package otrkeys helps import libotr "privkeys" files, which can contain
+// more than one account's private key.
+package otrkeys
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/twstrike/otr3"
+)
+
+var accountMarker = []byte("(account")
+
+var nameRe = regexp.MustCompile(`\(name\s+"([^"]*)"\)`)
+var protocolRe = regexp.MustCompile(`\(protocol\s+([a-zA-Z0-9_-]+)\)`)
+
+// ImportedKey is one successfully parsed identity from a libotr privkeys
+// file, paired with a human-readable label so callers can let the user pick
+// which one to use.
+type ImportedKey struct {
+	Key   otr3.PrivateKey
+	Label string
+}
+
+// ImportFromLibOTR scans raw for successive "(account ...)" blocks, as found
+// in a libotr privkeys file, and parses each one into an otr3.PrivateKey.
+// Blocks that fail to parse are skipped, so the returned slice's indices
+// always line up with its own Labels, even if some blocks are unparseable.
+func ImportFromLibOTR(raw []byte) []ImportedKey {
+	var imported []ImportedKey
+
+	for _, block := range accountBlocks(raw) {
+		var priv otr3.PrivateKey
+		if priv.Import(block) {
+			imported = append(imported, ImportedKey{Key: priv, Label: accountLabel(block)})
+		}
+	}
+
+	return imported
+}
+
+// accountLabel builds a human-readable "name (protocol)" label for a single
+// "(account ...)" block. A block missing a name or protocol yields a
+// best-effort label instead.
+func accountLabel(block []byte) string {
+	name := ""
+	if m := nameRe.FindSubmatch(block); m != nil {
+		name = string(m[1])
+	}
+	protocol := ""
+	if m := protocolRe.FindSubmatch(block); m != nil {
+		protocol = string(m[1])
+	}
+
+	switch {
+	case name != "" && protocol != "":
+		return name + " (" + protocol + ")"
+	case name != "":
+		return name
+	default:
+		return "(unknown account)"
+	}
+}
+
+// accountBlocks splits raw into the substrings starting at each "(account"
+// marker, up to (but not including) the next marker or the end of the file.
+// This mirrors the simplicity of otr3.PrivateKey.Import itself: it doesn't
+// try to be a real s-expression parser, just enough to separate one
+// account's worth of text from the next.
+func accountBlocks(raw []byte) [][]byte {
+	var starts []int
+	for i := 0; ; {
+		idx := bytes.Index(raw[i:], accountMarker)
+		if idx < 0 {
+			break
+		}
+		idx += i
+		starts = append(starts, idx)
+		i = idx + len(accountMarker)
+	}
+
+	blocks := make([][]byte, 0, len(starts))
+	for i, start := range starts {
+		end := len(raw)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		blocks = append(blocks, raw[start:end])
+	}
+
+	return blocks
+}
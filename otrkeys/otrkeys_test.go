@@ -0,0 +1,56 @@
+package otrkeys
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAccountBlocks(t *testing.T) {
+	raw := []byte(`(privkeys
+ (account
+  (name "alice@example.com")
+  (protocol prpl-jabber)
+ )
+ (account
+  (name "bob@example.com")
+  (protocol prpl-jabber)
+ )
+)`)
+
+	blocks := accountBlocks(raw)
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want 2", len(blocks))
+	}
+	if !bytes.Contains(blocks[0], []byte("alice@example.com")) {
+		t.Errorf("first block doesn't contain alice's account: %s", blocks[0])
+	}
+	if !bytes.Contains(blocks[1], []byte("bob@example.com")) {
+		t.Errorf("second block doesn't contain bob's account: %s", blocks[1])
+	}
+	if bytes.Contains(blocks[0], []byte("bob@example.com")) {
+		t.Errorf("first block leaked into the second account's data")
+	}
+}
+
+func TestAccountBlocksNoMarkers(t *testing.T) {
+	if blocks := accountBlocks([]byte("nothing interesting here")); blocks != nil {
+		t.Errorf("got %v, want nil", blocks)
+	}
+}
+
+func TestAccountLabel(t *testing.T) {
+	cases := []struct {
+		block []byte
+		want  string
+	}{
+		{[]byte(`(account (name "alice@example.com") (protocol prpl-jabber))`), "alice@example.com (prpl-jabber)"},
+		{[]byte(`(account (name "alice@example.com"))`), "alice@example.com"},
+		{[]byte(`(account)`), "(unknown account)"},
+	}
+
+	for _, c := range cases {
+		if got := accountLabel(c.block); got != c.want {
+			t.Errorf("accountLabel(%s) = %q, want %q", c.block, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package proxyreg
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/proxy"
+)
+
+// httpConnectDialer tunnels connections through an HTTP or HTTPS proxy using
+// the CONNECT method, with Basic auth taken from the proxy URL's userinfo
+// when present.
+type httpConnectDialer struct {
+	addr      string
+	tls       bool
+	forward   proxy.Dialer
+	basicAuth string
+}
+
+func newHTTPConnectDialer(u *url.URL, forward proxy.Dialer) (proxy.Dialer, error) {
+	addr := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	d := &httpConnectDialer{
+		addr:    addr,
+		tls:     u.Scheme == "https",
+		forward: forward,
+	}
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		// u.User.String() percent-encodes the userinfo per net/url's rules,
+		// which would send an escaped password to the proxy. Basic auth
+		// wants the raw username/password instead.
+		userInfo := u.User.Username() + ":" + pass
+		d.basicAuth = base64.StdEncoding.EncodeToString([]byte(userInfo))
+	}
+
+	return d, nil
+}
+
+func (d *httpConnectDialer) Dial(network, addr string) (net.Conn, error) {
+	conn, err := d.forward.Dial("tcp", d.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.tls {
+		conn = tls.Client(conn, &tls.Config{ServerName: hostOnly(d.addr)})
+	}
+
+	req := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if d.basicAuth != "" {
+		req.Header.Set("Proxy-Authorization", "Basic "+d.basicAuth)
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, errors.New("proxyreg: HTTP proxy CONNECT failed: " + resp.Status)
+	}
+
+	return conn, nil
+}
+
+func hostOnly(hostport string) string {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return hostport
+	}
+	return host
+}
@@ -0,0 +1,76 @@
+package proxyreg
+
+import (
+	"bufio"
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestNewHTTPConnectDialerBasicAuthUnescaped(t *testing.T) {
+	u := &url.URL{
+		Scheme: "http",
+		Host:   "proxy:8080",
+		User:   url.UserPassword("alice", "p@ss:w/ord"),
+	}
+
+	dialer, err := newHTTPConnectDialer(u, nil)
+	if err != nil {
+		t.Fatalf("newHTTPConnectDialer: %v", err)
+	}
+	d := dialer.(*httpConnectDialer)
+
+	if d.basicAuth == "" {
+		t.Fatal("expected basicAuth to be set")
+	}
+
+	// alice:p@ss:w/ord, base64-encoded with the raw (unescaped) password.
+	const want = "YWxpY2U6cEBzczp3L29yZA=="
+	if d.basicAuth != want {
+		t.Errorf("basicAuth = %q, want %q (password must not be percent-encoded)", d.basicAuth, want)
+	}
+}
+
+func TestHTTPConnectDialSendsRequestAndReadsReply(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	u := &url.URL{Scheme: "http", Host: "proxy:8080"}
+	dialer, err := newHTTPConnectDialer(u, fakeForwardDialer{conn: client})
+	if err != nil {
+		t.Fatalf("newHTTPConnectDialer: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := dialer.Dial("tcp", "example.com:443")
+		done <- err
+	}()
+
+	reader := bufio.NewReader(server)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading CONNECT line: %v", err)
+	}
+	if line != "CONNECT example.com:443 HTTP/1.1\r\n" {
+		t.Errorf("got request line %q", line)
+	}
+	// Drain headers until the blank line.
+	for {
+		l, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading headers: %v", err)
+		}
+		if l == "\r\n" {
+			break
+		}
+	}
+
+	if _, err := server.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		t.Fatalf("writing response: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+}
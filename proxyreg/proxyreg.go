@@ -0,0 +1,50 @@
+// Package proxyreg lets additional proxy URL schemes be plugged into
+// golang.org/x/net/proxy, which out of the box only understands socks5://
+// and direct connections.
+package proxyreg
+
+import (
+	"net/url"
+	"sync"
+
+	"golang.org/x/net/proxy"
+)
+
+// DialerFactory builds a proxy.Dialer that dials through the proxy
+// described by u, chaining through forward to reach that proxy itself.
+type DialerFactory func(u *url.URL, forward proxy.Dialer) (proxy.Dialer, error)
+
+var (
+	mu        sync.Mutex
+	factories = map[string]DialerFactory{}
+)
+
+// RegisterDialer associates scheme (e.g. "socks4", "ssh") with factory.
+// Registering the same scheme twice replaces the previous factory.
+func RegisterDialer(scheme string, factory DialerFactory) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[scheme] = factory
+}
+
+// FromURL builds a dialer for u, chaining through forward. Schemes with no
+// registered factory fall back to proxy.FromURL, so socks5:// and direct
+// connections keep working exactly as before.
+func FromURL(u *url.URL, forward proxy.Dialer) (proxy.Dialer, error) {
+	mu.Lock()
+	factory, ok := factories[u.Scheme]
+	mu.Unlock()
+
+	if !ok {
+		return proxy.FromURL(u, forward)
+	}
+	return factory(u, forward)
+}
+
+func init() {
+	RegisterDialer("socks4", newSocks4Dialer(false))
+	RegisterDialer("socks4a", newSocks4Dialer(true))
+	RegisterDialer("http", newHTTPConnectDialer)
+	RegisterDialer("https", newHTTPConnectDialer)
+	RegisterDialer("ssh", newSSHDialer)
+}
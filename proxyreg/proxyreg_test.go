@@ -0,0 +1,46 @@
+package proxyreg
+
+import (
+	"net/url"
+	"testing"
+
+	"golang.org/x/net/proxy"
+)
+
+func TestFromURLUsesRegisteredFactory(t *testing.T) {
+	called := false
+	RegisterDialer("test-scheme", func(u *url.URL, forward proxy.Dialer) (proxy.Dialer, error) {
+		called = true
+		return forward, nil
+	})
+
+	if _, err := FromURL(&url.URL{Scheme: "test-scheme", Host: "proxy:1234"}, proxy.Direct); err != nil {
+		t.Fatalf("FromURL: %v", err)
+	}
+	if !called {
+		t.Errorf("expected the registered factory to be called")
+	}
+}
+
+func TestRegisterDialerReplacesExistingScheme(t *testing.T) {
+	RegisterDialer("replace-me", func(u *url.URL, forward proxy.Dialer) (proxy.Dialer, error) {
+		return nil, nil
+	})
+	RegisterDialer("replace-me", func(u *url.URL, forward proxy.Dialer) (proxy.Dialer, error) {
+		return forward, nil
+	})
+
+	dialer, err := FromURL(&url.URL{Scheme: "replace-me"}, proxy.Direct)
+	if err != nil {
+		t.Fatalf("FromURL: %v", err)
+	}
+	if dialer != proxy.Direct {
+		t.Errorf("expected the second registration to win")
+	}
+}
+
+func TestFromURLFallsBackForUnregisteredScheme(t *testing.T) {
+	if _, err := FromURL(&url.URL{Scheme: "socks5", Host: "proxy:1080"}, proxy.Direct); err != nil {
+		t.Fatalf("FromURL should fall back to proxy.FromURL for socks5: %v", err)
+	}
+}
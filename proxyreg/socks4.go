@@ -0,0 +1,99 @@
+package proxyreg
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+
+	"golang.org/x/net/proxy"
+)
+
+// socks4Dialer implements the (simpler, IPv4-only) SOCKS4 and SOCKS4a
+// protocols. SOCKS4a differs only in that the destination host name is sent
+// to the proxy instead of a resolved IP, so the proxy does the DNS lookup.
+type socks4Dialer struct {
+	addr    string
+	forward proxy.Dialer
+	socks4a bool
+}
+
+func newSocks4Dialer(socks4a bool) DialerFactory {
+	return func(u *url.URL, forward proxy.Dialer) (proxy.Dialer, error) {
+		if u.Host == "" {
+			return nil, errors.New("proxyreg: socks4 proxy URL has no host")
+		}
+		return &socks4Dialer{addr: u.Host, forward: forward, socks4a: socks4a}, nil
+	}
+}
+
+func (d *socks4Dialer) Dial(network, addr string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := d.forward.Dial("tcp", d.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	req := []byte{0x04, 0x01}
+	req = appendUint16(req, uint16(port))
+
+	ip := net.ParseIP(host)
+	useDomain := d.socks4a && ip == nil
+	if useDomain {
+		req = append(req, 0, 0, 0, 1)
+	} else {
+		if ip == nil {
+			ips, err := net.LookupIP(host)
+			if err != nil || len(ips) == 0 {
+				conn.Close()
+				return nil, errors.New("proxyreg: could not resolve " + host + " for SOCKS4")
+			}
+			ip = ips[0]
+		}
+		ip4 := ip.To4()
+		if ip4 == nil {
+			conn.Close()
+			return nil, errors.New("proxyreg: SOCKS4 only supports IPv4 addresses, got " + host)
+		}
+		req = append(req, ip4...)
+	}
+
+	req = append(req, 0) // empty USERID
+	if useDomain {
+		req = append(req, []byte(host)...)
+		req = append(req, 0)
+	}
+
+	if _, err := conn.Write(req); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reply := make([]byte, 8)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if reply[1] != 0x5a {
+		conn.Close()
+		return nil, errors.New("proxyreg: SOCKS4 proxy refused connection, code " + strconv.Itoa(int(reply[1])))
+	}
+
+	return conn, nil
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], v)
+	return append(b, buf[:]...)
+}
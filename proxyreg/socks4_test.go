@@ -0,0 +1,121 @@
+package proxyreg
+
+import (
+	"io"
+	"net"
+	"net/url"
+	"testing"
+)
+
+// fakeForwardDialer hands back one end of a net.Pipe and lets the test drive
+// the other end as a fake SOCKS4 proxy.
+type fakeForwardDialer struct {
+	conn net.Conn
+}
+
+func (d fakeForwardDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.conn, nil
+}
+
+func TestSocks4DialRequest(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	factory := newSocks4Dialer(false)
+	dialer, err := factory(&url.URL{Host: "proxy:1080"}, fakeForwardDialer{conn: client})
+	if err != nil {
+		t.Fatalf("newSocks4Dialer factory: %v", err)
+	}
+
+	done := make(chan struct{})
+	var dialErr error
+	go func() {
+		_, dialErr = dialer.Dial("tcp", "93.184.216.34:80")
+		close(done)
+	}()
+
+	req := make([]byte, 9)
+	if _, err := io.ReadFull(server, req); err != nil {
+		t.Fatalf("reading request: %v", err)
+	}
+	if req[0] != 0x04 || req[1] != 0x01 {
+		t.Errorf("got version/command %v, want [4 1]", req[:2])
+	}
+	if req[2] != 0x00 || req[3] != 0x50 {
+		t.Errorf("got port bytes %v, want 80", req[2:4])
+	}
+	if req[4] != 93 || req[5] != 184 || req[6] != 216 || req[7] != 34 {
+		t.Errorf("got IP bytes %v, want 93.184.216.34", req[4:8])
+	}
+	if req[8] != 0 {
+		t.Errorf("got USERID terminator %v, want 0", req[8])
+	}
+
+	if _, err := server.Write([]byte{0x00, 0x5a, 0, 0, 0, 0, 0, 0}); err != nil {
+		t.Fatalf("writing reply: %v", err)
+	}
+
+	<-done
+	if dialErr != nil {
+		t.Fatalf("Dial: %v", dialErr)
+	}
+}
+
+func TestSocks4DialRejected(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	factory := newSocks4Dialer(false)
+	dialer, err := factory(&url.URL{Host: "proxy:1080"}, fakeForwardDialer{conn: client})
+	if err != nil {
+		t.Fatalf("newSocks4Dialer factory: %v", err)
+	}
+
+	done := make(chan struct{})
+	var dialErr error
+	go func() {
+		_, dialErr = dialer.Dial("tcp", "93.184.216.34:80")
+		close(done)
+	}()
+
+	req := make([]byte, 9)
+	io.ReadFull(server, req)
+	server.Write([]byte{0x00, 0x5b, 0, 0, 0, 0, 0, 0})
+
+	<-done
+	if dialErr == nil {
+		t.Errorf("expected Dial to fail on a rejected reply")
+	}
+}
+
+func TestSocks4aUsesDomainName(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	factory := newSocks4Dialer(true)
+	dialer, err := factory(&url.URL{Host: "proxy:1080"}, fakeForwardDialer{conn: client})
+	if err != nil {
+		t.Fatalf("newSocks4Dialer factory: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		dialer.Dial("tcp", "example.com:80")
+		close(done)
+	}()
+
+	req := make([]byte, 9+len("example.com")+1)
+	if _, err := io.ReadFull(server, req); err != nil {
+		t.Fatalf("reading request: %v", err)
+	}
+	if req[4] != 0 || req[5] != 0 || req[6] != 0 || req[7] != 1 {
+		t.Errorf("got IP placeholder %v, want 0.0.0.1", req[4:8])
+	}
+	domain := req[9 : len(req)-1]
+	if string(domain) != "example.com" {
+		t.Errorf("got domain %q, want %q", domain, "example.com")
+	}
+
+	server.Write([]byte{0x00, 0x5a, 0, 0, 0, 0, 0, 0})
+	<-done
+}
@@ -0,0 +1,288 @@
+package proxyreg
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"net"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestNewSSHDialerDefaultPort(t *testing.T) {
+	u := &url.URL{Scheme: "ssh", Host: "example.com", User: url.UserPassword("bob", "secret")}
+
+	dialer, err := newSSHDialer(u, nil)
+	if err != nil {
+		t.Fatalf("newSSHDialer: %v", err)
+	}
+	d := dialer.(*sshDialer)
+
+	if d.addr != "example.com:22" {
+		t.Errorf("addr = %q, want %q", d.addr, "example.com:22")
+	}
+	if d.user != "bob" || d.pass != "secret" {
+		t.Errorf("got user=%q pass=%q, want bob/secret", d.user, d.pass)
+	}
+}
+
+func TestNewSSHDialerExplicitPort(t *testing.T) {
+	u := &url.URL{Scheme: "ssh", Host: "example.com:2222"}
+
+	dialer, err := newSSHDialer(u, nil)
+	if err != nil {
+		t.Fatalf("newSSHDialer: %v", err)
+	}
+	d := dialer.(*sshDialer)
+
+	if d.addr != "example.com:2222" {
+		t.Errorf("addr = %q, want %q", d.addr, "example.com:2222")
+	}
+}
+
+func TestNewSSHDialerNoHost(t *testing.T) {
+	if _, err := newSSHDialer(&url.URL{Scheme: "ssh"}, nil); err == nil {
+		t.Errorf("expected an error for a host-less ssh:// URL")
+	}
+}
+
+func TestNewSSHDialerDoesNotDial(t *testing.T) {
+	// Constructing a dialer must not touch the network: pass a nil forward
+	// dialer and confirm newSSHDialer never dereferences it.
+	if _, err := newSSHDialer(&url.URL{Scheme: "ssh", Host: "example.com"}, nil); err != nil {
+		t.Fatalf("newSSHDialer: %v", err)
+	}
+}
+
+// countingForwardDialer counts how many times Dial is called, so tests can
+// assert the SSH connection underneath an sshDialer is established once and
+// reused, rather than reconnecting for every tunneled Dial.
+type countingForwardDialer struct {
+	addr  string
+	count int32
+}
+
+func (d *countingForwardDialer) Dial(network, addr string) (net.Conn, error) {
+	atomic.AddInt32(&d.count, 1)
+	return net.Dial("tcp", d.addr)
+}
+
+// startFakeSSHServerClosingAfterHandshake is like startFakeSSHServer, but
+// immediately closes each connection once the handshake completes, to
+// simulate a server restart or dropped connection.
+func startFakeSSHServerClosingAfterHandshake(t *testing.T) (net.Listener, ssh.PublicKey) {
+	config, ln, hostKey := fakeSSHServerConfig(t)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			sconn, _, _, err := ssh.NewServerConn(conn, config)
+			if err != nil {
+				continue
+			}
+			sconn.Close()
+		}
+	}()
+
+	return ln, hostKey
+}
+
+// startFakeSSHServer accepts connections and completes the SSH handshake
+// with any password, then rejects every channel — enough to drive a real
+// client handshake without implementing actual tunneling. It returns the
+// server's host public key alongside the listener, so tests can pin it.
+func startFakeSSHServer(t *testing.T) (net.Listener, ssh.PublicKey) {
+	config, ln, hostKey := fakeSSHServerConfig(t)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				sconn, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				defer sconn.Close()
+				go ssh.DiscardRequests(reqs)
+				for newChan := range chans {
+					newChan.Reject(ssh.Prohibited, "no channels in this fake server")
+				}
+			}()
+		}
+	}()
+
+	return ln, hostKey
+}
+
+// fakeSSHServerConfig builds a server config that accepts any password, a
+// listener for tests to accept connections on, and the server's host public
+// key so tests can pin it.
+func fakeSSHServerConfig(t *testing.T) (*ssh.ServerConfig, net.Listener, ssh.PublicKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("ssh.NewSignerFromKey: %v", err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+
+	return config, ln, signer.PublicKey()
+}
+
+func TestSSHDialerReusesConnection(t *testing.T) {
+	ln, hostKey := startFakeSSHServer(t)
+	defer ln.Close()
+
+	forward := &countingForwardDialer{addr: ln.Addr().String()}
+	dialer, err := newSSHDialer(&url.URL{
+		Scheme:   "ssh",
+		Host:     "proxy.example.com",
+		User:     url.UserPassword("bob", "secret"),
+		RawQuery: "host-key-sha256=" + sshHostKeyFingerprint(hostKey),
+	}, forward)
+	if err != nil {
+		t.Fatalf("newSSHDialer: %v", err)
+	}
+
+	// The fake server rejects every channel, so each Dial is expected to
+	// fail — we only care how many times the underlying TCP connection to
+	// the SSH server itself was established.
+	dialer.Dial("tcp", "target1.example.com:80")
+	dialer.Dial("tcp", "target2.example.com:80")
+
+	if got := atomic.LoadInt32(&forward.count); got != 1 {
+		t.Errorf("forward.Dial called %d times, want 1 (the SSH connection should be cached and reused)", got)
+	}
+}
+
+func TestSSHDialerReconnectsAfterConnectionDies(t *testing.T) {
+	ln, hostKey := startFakeSSHServerClosingAfterHandshake(t)
+	defer ln.Close()
+
+	forward := &countingForwardDialer{addr: ln.Addr().String()}
+	dialer, err := newSSHDialer(&url.URL{
+		Scheme:   "ssh",
+		Host:     "proxy.example.com",
+		User:     url.UserPassword("bob", "secret"),
+		RawQuery: "host-key-sha256=" + sshHostKeyFingerprint(hostKey),
+	}, forward)
+	if err != nil {
+		t.Fatalf("newSSHDialer: %v", err)
+	}
+	d := dialer.(*sshDialer)
+
+	// Establish the (immediately-dead) connection once.
+	if _, err := d.sshClient(); err != nil {
+		t.Fatalf("sshClient: %v", err)
+	}
+
+	// The server closed the connection right after the handshake; wait for
+	// that to be noticed and the cached client dropped.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		d.mu.Lock()
+		cleared := d.client == nil
+		d.mu.Unlock()
+		if cleared {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cached SSH client was never cleared after the connection died")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A subsequent Dial must reconnect rather than reuse the dead client.
+	if _, err := d.sshClient(); err != nil {
+		t.Fatalf("sshClient after reconnect: %v", err)
+	}
+	if got := atomic.LoadInt32(&forward.count); got != 2 {
+		t.Errorf("forward.Dial called %d times, want 2 (should reconnect after the first connection died)", got)
+	}
+}
+
+func TestSSHDialerFailsClosedWithoutPin(t *testing.T) {
+	ln, _ := startFakeSSHServer(t)
+	defer ln.Close()
+
+	forward := &countingForwardDialer{addr: ln.Addr().String()}
+	dialer, err := newSSHDialer(&url.URL{
+		Scheme: "ssh",
+		Host:   "proxy.example.com",
+		User:   url.UserPassword("bob", "secret"),
+	}, forward)
+	if err != nil {
+		t.Fatalf("newSSHDialer: %v", err)
+	}
+	d := dialer.(*sshDialer)
+
+	if _, err := d.sshClient(); err == nil {
+		t.Error("expected sshClient to fail closed when no host-key-sha256 pin is configured")
+	}
+}
+
+func TestSSHDialerRejectsMismatchedPin(t *testing.T) {
+	ln, _ := startFakeSSHServer(t)
+	defer ln.Close()
+
+	forward := &countingForwardDialer{addr: ln.Addr().String()}
+	dialer, err := newSSHDialer(&url.URL{
+		Scheme:   "ssh",
+		Host:     "proxy.example.com",
+		User:     url.UserPassword("bob", "secret"),
+		RawQuery: "host-key-sha256=" + strings.Repeat("00", sha256.Size),
+	}, forward)
+	if err != nil {
+		t.Fatalf("newSSHDialer: %v", err)
+	}
+	d := dialer.(*sshDialer)
+
+	if _, err := d.sshClient(); err == nil {
+		t.Error("expected sshClient to reject a mismatched host-key-sha256 pin")
+	}
+}
+
+func TestSSHDialerAcceptsMatchingPin(t *testing.T) {
+	ln, hostKey := startFakeSSHServer(t)
+	defer ln.Close()
+
+	forward := &countingForwardDialer{addr: ln.Addr().String()}
+	dialer, err := newSSHDialer(&url.URL{
+		Scheme:   "ssh",
+		Host:     "proxy.example.com",
+		User:     url.UserPassword("bob", "secret"),
+		RawQuery: "host-key-sha256=" + sshHostKeyFingerprint(hostKey),
+	}, forward)
+	if err != nil {
+		t.Fatalf("newSSHDialer: %v", err)
+	}
+	d := dialer.(*sshDialer)
+
+	if _, err := d.sshClient(); err != nil {
+		t.Errorf("sshClient: %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+// Package tordiscovery finds a local Tor SOCKS proxy and uses it to look up
+// a domain's onion-service XMPP endpoint via its .well-known/xmpp-onion
+// document, instead of relying on a static, hand-maintained list of known
+// servers.
+package tordiscovery
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// defaultSOCKSPorts are the ports the Tor Browser Bundle and the system tor
+// package conventionally listen on.
+var defaultSOCKSPorts = []string{"9050", "9150"}
+
+// defaultControlPort is the default Tor control port.
+const defaultControlPort = "9051"
+
+const dialTimeout = 2 * time.Second
+
+// DiscoverSOCKSProxy finds a local Tor SOCKS proxy to use. It first asks the
+// control port, if reachable, which address it's actually listening on via
+// "GETINFO net/listeners/socks", and falls back to probing the conventional
+// 9050/9150 ports directly.
+func DiscoverSOCKSProxy() (string, error) {
+	if addr, err := socksFromControlPort(); err == nil {
+		return addr, nil
+	}
+
+	for _, port := range defaultSOCKSPorts {
+		addr := net.JoinHostPort("127.0.0.1", port)
+		conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+		if err == nil {
+			conn.Close()
+			return addr, nil
+		}
+	}
+
+	return "", errors.New("tordiscovery: no local Tor SOCKS proxy found")
+}
+
+func socksFromControlPort() (string, error) {
+	return socksFromControlPortAt(net.JoinHostPort("127.0.0.1", defaultControlPort))
+}
+
+// socksFromControlPortAt is socksFromControlPort with the control port
+// address broken out so it can be pointed at a fake control port in tests.
+func socksFromControlPortAt(addr string) (string, error) {
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	if err := authenticate(conn, r); err != nil {
+		return "", err
+	}
+
+	if _, err := fmt.Fprint(conn, "GETINFO net/listeners/socks\r\n"); err != nil {
+		return "", err
+	}
+
+	lines, err := readControlReply(r)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "net/listeners/socks=") {
+			listeners := strings.TrimPrefix(line, "net/listeners/socks=")
+			fields := strings.Fields(listeners)
+			if len(fields) == 0 {
+				return "", errors.New("tordiscovery: control port reported no SOCKS listeners")
+			}
+			return strings.Trim(fields[0], "\""), nil
+		}
+	}
+
+	return "", errors.New("tordiscovery: control port did not report a SOCKS listener")
+}
+
+var authLineRe = regexp.MustCompile(`^AUTH METHODS=(\S+)(?: COOKIEFILE="([^"]*)")?`)
+
+// authenticate completes the Tor control port's AUTHENTICATE handshake,
+// using whichever method PROTOCOLINFO reports as available: a cookie read
+// off disk, or (if the port has auth fully disabled) no credentials at all.
+// SAFECOOKIE and HASHEDPASSWORD aren't supported, since the former needs a
+// challenge/response exchange and the latter needs a password we don't
+// have.
+func authenticate(conn net.Conn, r *bufio.Reader) error {
+	if _, err := fmt.Fprint(conn, "PROTOCOLINFO 1\r\n"); err != nil {
+		return err
+	}
+	lines, err := readControlReply(r)
+	if err != nil {
+		return err
+	}
+
+	var methods []string
+	var cookieFile string
+	for _, line := range lines {
+		if m := authLineRe.FindStringSubmatch(line); m != nil {
+			methods = strings.Split(m[1], ",")
+			cookieFile = m[2]
+		}
+	}
+
+	arg := ""
+	switch {
+	case containsMethod(methods, "NULL"):
+		// No credentials needed.
+	case containsMethod(methods, "COOKIE") && cookieFile != "":
+		cookie, err := ioutil.ReadFile(cookieFile)
+		if err != nil {
+			return errors.New("tordiscovery: reading control port cookie file: " + err.Error())
+		}
+		arg = " " + hex.EncodeToString(cookie)
+	default:
+		return errors.New("tordiscovery: control port requires an unsupported auth method (SAFECOOKIE/HASHEDPASSWORD)")
+	}
+
+	if _, err := fmt.Fprint(conn, "AUTHENTICATE"+arg+"\r\n"); err != nil {
+		return err
+	}
+	if _, err := readControlReply(r); err != nil {
+		return errors.New("tordiscovery: control port authentication failed: " + err.Error())
+	}
+	return nil
+}
+
+func containsMethod(methods []string, want string) bool {
+	for _, m := range methods {
+		if m == want {
+			return true
+		}
+	}
+	return false
+}
+
+// readControlReply reads lines from the Tor control protocol until the
+// final "250 OK"-style line, stripping the "250-"/"250+" continuation
+// prefix from each line it returns. A non-"2xx" status line is reported as
+// an error, per the control-spec reply grammar.
+func readControlReply(r *bufio.Reader) ([]string, error) {
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if len(line) < 4 || line[0] != '2' {
+			return nil, errors.New("tordiscovery: control port error: " + line)
+		}
+
+		switch line[3] {
+		case ' ':
+			return lines, nil
+		case '-', '+':
+			lines = append(lines, line[4:])
+		default:
+			return nil, errors.New("tordiscovery: malformed control port reply: " + line)
+		}
+	}
+}
+
+// OnionRecord is the JSON document published at
+// https://<domain>/.well-known/xmpp-onion.
+type OnionRecord struct {
+	C2S        string `json:"c2s"`
+	CertSHA256 string `json:"cert_sha256"`
+}
+
+// FetchOnionRecord fetches and parses domain's .well-known/xmpp-onion
+// document, dialing through the given SOCKS proxy address so the lookup
+// itself doesn't leak over the clearnet.
+func FetchOnionRecord(socksAddr, domain string) (*OnionRecord, error) {
+	dialer, err := proxy.SOCKS5("tcp", socksAddr, nil, proxy.Direct)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{Dial: dialer.Dial},
+		Timeout:   30 * time.Second,
+	}
+
+	resp, err := client.Get("https://" + domain + "/.well-known/xmpp-onion")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tordiscovery: %s returned %s", domain, resp.Status)
+	}
+
+	var record OnionRecord
+	if err := json.NewDecoder(resp.Body).Decode(&record); err != nil {
+		return nil, err
+	}
+	if record.C2S == "" {
+		return nil, errors.New("tordiscovery: xmpp-onion document has no c2s field")
+	}
+
+	return &record, nil
+}
@@ -0,0 +1,144 @@
+package tordiscovery
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOnionRecordUnmarshal(t *testing.T) {
+	raw := `{"c2s": "onion-address.onion:5222", "cert_sha256": "deadbeef"}`
+
+	var record OnionRecord
+	if err := json.Unmarshal([]byte(raw), &record); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if record.C2S != "onion-address.onion:5222" {
+		t.Errorf("C2S = %q, want %q", record.C2S, "onion-address.onion:5222")
+	}
+	if record.CertSHA256 != "deadbeef" {
+		t.Errorf("CertSHA256 = %q, want %q", record.CertSHA256, "deadbeef")
+	}
+}
+
+// fakeControlPort pretends to be a Tor control port for exactly the
+// exchange socksFromControlPort performs, replying to PROTOCOLINFO with the
+// given authLine (e.g. `AUTH METHODS=NULL` or
+// `AUTH METHODS=COOKIE COOKIEFILE="/path/to/cookie"`) and to GETINFO with
+// listenerLine, both using the real "250-"/"250 OK" reply grammar.
+func fakeControlPort(t *testing.T, authLine, listenerLine string) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+
+		r.ReadString('\n') // PROTOCOLINFO 1
+		conn.Write([]byte("250-PROTOCOLINFO 1\r\n"))
+		conn.Write([]byte("250-" + authLine + "\r\n"))
+		conn.Write([]byte("250 OK\r\n"))
+
+		r.ReadString('\n') // AUTHENTICATE [cookie]
+		conn.Write([]byte("250 OK\r\n"))
+
+		r.ReadString('\n') // GETINFO net/listeners/socks
+		conn.Write([]byte("250-" + listenerLine + "\r\n"))
+		conn.Write([]byte("250 OK\r\n"))
+	}()
+
+	return ln
+}
+
+func TestSocksFromControlPortNullAuth(t *testing.T) {
+	ln := fakeControlPort(t, `AUTH METHODS=NULL`, `net/listeners/socks="127.0.0.1:9999"`)
+	defer ln.Close()
+
+	addr, err := socksFromControlPortAt(ln.Addr().String())
+	if err != nil {
+		t.Fatalf("socksFromControlPortAt: %v", err)
+	}
+	if addr != "127.0.0.1:9999" {
+		t.Errorf("addr = %q, want %q", addr, "127.0.0.1:9999")
+	}
+}
+
+func TestSocksFromControlPortCookieAuth(t *testing.T) {
+	cookie := []byte{0xde, 0xad, 0xbe, 0xef}
+	cookiePath := filepath.Join(t.TempDir(), "control_auth_cookie")
+	if err := os.WriteFile(cookiePath, cookie, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var gotAuth string
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+
+		r.ReadString('\n') // PROTOCOLINFO 1
+		conn.Write([]byte("250-PROTOCOLINFO 1\r\n"))
+		conn.Write([]byte(`250-AUTH METHODS=COOKIE COOKIEFILE="` + cookiePath + "\"\r\n"))
+		conn.Write([]byte("250 OK\r\n"))
+
+		authLine, _ := r.ReadString('\n')
+		gotAuth = authLine
+		conn.Write([]byte("250 OK\r\n"))
+
+		r.ReadString('\n') // GETINFO net/listeners/socks
+		conn.Write([]byte(`250-net/listeners/socks="127.0.0.1:9999"` + "\r\n"))
+		conn.Write([]byte("250 OK\r\n"))
+	}()
+
+	addr, err := socksFromControlPortAt(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("socksFromControlPortAt: %v", err)
+	}
+	if addr != "127.0.0.1:9999" {
+		t.Errorf("addr = %q, want %q", addr, "127.0.0.1:9999")
+	}
+
+	wantAuth := "AUTHENTICATE " + hex.EncodeToString(cookie) + "\r\n"
+	if gotAuth != wantAuth {
+		t.Errorf("AUTHENTICATE line = %q, want %q", gotAuth, wantAuth)
+	}
+}
+
+func TestSocksFromControlPortNoListener(t *testing.T) {
+	ln := fakeControlPort(t, `AUTH METHODS=NULL`, `net/listeners/socks=`)
+	defer ln.Close()
+
+	if _, err := socksFromControlPortAt(ln.Addr().String()); err == nil {
+		t.Errorf("expected an error when the control port reports no SOCKS listeners")
+	}
+}
+
+func TestSocksFromControlPortUnsupportedAuth(t *testing.T) {
+	ln := fakeControlPort(t, `AUTH METHODS=SAFECOOKIE,HASHEDPASSWORD`, `net/listeners/socks="127.0.0.1:9999"`)
+	defer ln.Close()
+
+	if _, err := socksFromControlPortAt(ln.Addr().String()); err == nil {
+		t.Errorf("expected an error for an auth method we don't support")
+	}
+}
@@ -0,0 +1,60 @@
+// Package trustpin builds per-domain TLS trust policy: a set of root
+// certificates to trust exclusively, and/or a set of pinned SPKI hashes,
+// loaded from files so distributions don't have to ship embedded DERs (as
+// coyim used to for jabber.ccc.de's CACert root).
+package trustpin
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+)
+
+// LoadRoots reads each PEM file in files and returns a pool containing all
+// the certificates found in them.
+func LoadRoots(files []string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range files {
+		pemBytes, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, errors.New("trustpin: no certificates found in " + file)
+		}
+	}
+	return pool, nil
+}
+
+// SPKIHash returns the hex-encoded SHA-256 hash of cert's DER-encoded
+// SubjectPublicKeyInfo. Unlike a whole-certificate hash, it survives
+// re-issuance of a certificate that keeps the same key.
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyPeerCertificate builds a tls.Config.VerifyPeerCertificate callback
+// that rejects the handshake unless at least one certificate in the
+// presented chain has an SPKI hash in pins (hex-encoded SHA-256).
+func VerifyPeerCertificate(pins []string) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	allowed := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		allowed[pin] = true
+	}
+
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				continue
+			}
+			if allowed[SPKIHash(cert)] {
+				return nil
+			}
+		}
+		return errors.New("trustpin: no certificate in the chain matched a pinned SPKI hash")
+	}
+}
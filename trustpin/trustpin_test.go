@@ -0,0 +1,72 @@
+package trustpin
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func generateTestCert(t *testing.T, commonName string) *x509.Certificate {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Unix(0, 0).Add(24 * time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate: %v", err)
+	}
+	return cert
+}
+
+func TestSPKIHashIsDeterministic(t *testing.T) {
+	cert := generateTestCert(t, "example.com")
+
+	if SPKIHash(cert) != SPKIHash(cert) {
+		t.Errorf("SPKIHash should return the same value for the same certificate")
+	}
+}
+
+func TestSPKIHashDiffersAcrossKeys(t *testing.T) {
+	a := generateTestCert(t, "example.com")
+	b := generateTestCert(t, "example.com")
+
+	if SPKIHash(a) == SPKIHash(b) {
+		t.Errorf("certificates with different keys should have different SPKI hashes")
+	}
+}
+
+func TestVerifyPeerCertificateAccepts(t *testing.T) {
+	cert := generateTestCert(t, "example.com")
+	verify := VerifyPeerCertificate([]string{SPKIHash(cert)})
+
+	if err := verify([][]byte{cert.Raw}, nil); err != nil {
+		t.Errorf("expected a pinned certificate to be accepted, got %v", err)
+	}
+}
+
+func TestVerifyPeerCertificateRejects(t *testing.T) {
+	cert := generateTestCert(t, "example.com")
+	other := generateTestCert(t, "other.example.com")
+	verify := VerifyPeerCertificate([]string{SPKIHash(other)})
+
+	if err := verify([][]byte{cert.Raw}, nil); err == nil {
+		t.Errorf("expected a certificate not in the pin set to be rejected")
+	}
+}
@@ -0,0 +1,53 @@
+package xmpplog
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var topLevelElementRe = regexp.MustCompile(`^\s*<\s*([a-zA-Z0-9:_-]+)`)
+
+// jsonlSink writes one JSON object per stanza: a timestamp, direction, and
+// the parsed top-level element name (e.g. "iq", "message", "presence").
+type jsonlSink struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+func newJSONLSink(path string) (Sink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonlSink{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+type jsonlRecord struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	Element   string    `json:"element,omitempty"`
+}
+
+func (s *jsonlSink) WriteStanza(dir Direction, b []byte) {
+	element := ""
+	if m := topLevelElementRe.FindSubmatch(b); m != nil {
+		element = string(m[1])
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Errors writing the log are deliberately swallowed: losing a log line
+	// shouldn't take down the XMPP connection.
+	_ = s.enc.Encode(jsonlRecord{Time: time.Now(), Direction: dir.String(), Element: element})
+}
+
+func (s *jsonlSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
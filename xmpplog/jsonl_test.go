@@ -0,0 +1,26 @@
+package xmpplog
+
+import "testing"
+
+func TestTopLevelElementRe(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{`<message to="a@b"><body>hi</body></message>`, "message"},
+		{`  <iq type='set'/>`, "iq"},
+		{`<presence/>`, "presence"},
+		{`not xml at all`, ""},
+	}
+
+	for _, c := range cases {
+		m := topLevelElementRe.FindStringSubmatch(c.in)
+		got := ""
+		if m != nil {
+			got = m[1]
+		}
+		if got != c.want {
+			t.Errorf("topLevelElementRe on %q = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
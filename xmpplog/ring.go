@@ -0,0 +1,57 @@
+package xmpplog
+
+import "sync"
+
+// Entry is one recorded stanza, suitable for a UI to render.
+type Entry struct {
+	Dir  Direction
+	Data []byte
+}
+
+// RingBuffer is an in-memory Sink holding the last N stanzas, for a UI to
+// display recent traffic. It's safe for concurrent use.
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// NewRingBuffer creates a RingBuffer holding up to capacity entries.
+func NewRingBuffer(capacity int) *RingBuffer {
+	return &RingBuffer{entries: make([]Entry, capacity)}
+}
+
+func (r *RingBuffer) WriteStanza(dir Direction, b []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cp := make([]byte, len(b))
+	copy(cp, b)
+
+	r.entries[r.next] = Entry{Dir: dir, Data: cp}
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Entries returns the recorded entries, oldest first.
+func (r *RingBuffer) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		out := make([]Entry, r.next)
+		copy(out, r.entries[:r.next])
+		return out
+	}
+
+	out := make([]Entry, len(r.entries))
+	copy(out, r.entries[r.next:])
+	copy(out[len(r.entries)-r.next:], r.entries[:r.next])
+	return out
+}
+
+// Close is a no-op; the ring buffer doesn't own any external resource.
+func (r *RingBuffer) Close() error { return nil }
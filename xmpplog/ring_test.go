@@ -0,0 +1,52 @@
+package xmpplog
+
+import "testing"
+
+func entryStrings(entries []Entry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = string(e.Data)
+	}
+	return out
+}
+
+func TestRingBufferBeforeWraparound(t *testing.T) {
+	rb := NewRingBuffer(3)
+	rb.WriteStanza(Outgoing, []byte("a"))
+	rb.WriteStanza(Outgoing, []byte("b"))
+
+	got := entryStrings(rb.Entries())
+	want := []string{"a", "b"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Entries() = %v, want %v", got, want)
+	}
+}
+
+func TestRingBufferWraparound(t *testing.T) {
+	rb := NewRingBuffer(3)
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		rb.WriteStanza(Outgoing, []byte(s))
+	}
+
+	got := entryStrings(rb.Entries())
+	want := []string{"c", "d", "e"}
+	if len(got) != len(want) {
+		t.Fatalf("Entries() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Entries()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRingBufferDirectionPreserved(t *testing.T) {
+	rb := NewRingBuffer(2)
+	rb.WriteStanza(Incoming, []byte("in"))
+	rb.WriteStanza(Outgoing, []byte("out"))
+
+	entries := rb.Entries()
+	if entries[0].Dir != Incoming || entries[1].Dir != Outgoing {
+		t.Errorf("directions not preserved: %+v", entries)
+	}
+}
@@ -0,0 +1,129 @@
+package xmpplog
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatingFileSink writes "<- "/"-> " prefixed raw stanza bytes to a file,
+// rotating it once it exceeds a size or age threshold, similar to
+// lumberjack.
+type rotatingFileSink struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	size     int64
+	opened   time.Time
+	maxBytes int64
+	maxAge   time.Duration
+}
+
+func newRotatingFileSink(path, rotate string) (Sink, error) {
+	s := &rotatingFileSink{path: path}
+
+	if rotate != "" {
+		if maxBytes, err := parseSize(rotate); err == nil {
+			s.maxBytes = maxBytes
+		} else if dur, err := time.ParseDuration(rotate); err == nil {
+			s.maxAge = dur
+		} else {
+			return nil, fmt.Errorf("xmpplog: invalid rotate=%q", rotate)
+		}
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *rotatingFileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.size = info.Size()
+	s.opened = time.Now()
+	return nil
+}
+
+func (s *rotatingFileSink) rotateIfNeeded(next int) {
+	needsRotation := (s.maxBytes > 0 && s.size+int64(next) > s.maxBytes) ||
+		(s.maxAge > 0 && time.Since(s.opened) > s.maxAge)
+	if !needsRotation {
+		return
+	}
+
+	s.file.Close()
+	rotated := s.path + "." + time.Now().Format("20060102T150405")
+	os.Rename(s.path, rotated)
+	if err := s.open(); err != nil {
+		// Nothing more we can do here; leave s.file nil so subsequent
+		// writes are dropped rather than panicking.
+		s.file = nil
+	}
+}
+
+func (s *rotatingFileSink) WriteStanza(dir Direction, b []byte) {
+	prefix := "-> "
+	if dir == Incoming {
+		prefix = "<- "
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rotateIfNeeded(len(prefix) + len(b) + 1)
+
+	if s.file == nil {
+		return
+	}
+
+	n, _ := fmt.Fprintf(s.file, "%s%s\n", prefix, b)
+	s.size += int64(n)
+}
+
+func (s *rotatingFileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(s, "GB"):
+		mult = 1 << 30
+		s = strings.TrimSuffix(s, "GB")
+	case strings.HasSuffix(s, "MB"):
+		mult = 1 << 20
+		s = strings.TrimSuffix(s, "MB")
+	case strings.HasSuffix(s, "KB"):
+		mult = 1 << 10
+		s = strings.TrimSuffix(s, "KB")
+	case strings.HasSuffix(s, "B"):
+		s = strings.TrimSuffix(s, "B")
+	default:
+		return 0, fmt.Errorf("xmpplog: unrecognized size %q", s)
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * mult, nil
+}
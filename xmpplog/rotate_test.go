@@ -0,0 +1,33 @@
+package xmpplog
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int64
+	}{
+		{"10B", 10},
+		{"1KB", 1 << 10},
+		{"10MB", 10 << 20},
+		{"2GB", 2 << 30},
+		{" 5 MB ", 5 << 20},
+	}
+
+	for _, c := range cases {
+		got, err := parseSize(c.in)
+		if err != nil {
+			t.Errorf("parseSize(%q): %v", c.in, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("parseSize(%q) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+func TestParseSizeInvalid(t *testing.T) {
+	if _, err := parseSize("not-a-size"); err == nil {
+		t.Errorf("expected an error for an unrecognized size string")
+	}
+}
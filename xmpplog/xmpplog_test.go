@@ -0,0 +1,72 @@
+package xmpplog
+
+import "testing"
+
+func TestRedactStripsAuthPayload(t *testing.T) {
+	in := []byte(`<auth mechanism="PLAIN" xmlns="urn:ietf:params:xml:ns:xmpp-sasl">AGFsaWNlAHNlY3JldA==</auth>`)
+
+	out := Redact(in)
+
+	if string(out) != `<auth mechanism="PLAIN" xmlns="urn:ietf:params:xml:ns:xmpp-sasl">[redacted]</auth>` {
+		t.Errorf("Redact = %s", out)
+	}
+}
+
+func TestRedactStripsOTRCiphertext(t *testing.T) {
+	in := []byte(`<body>?OTR:AAMDJ+MVmSfZ0DiE.</body>`)
+
+	out := Redact(in)
+
+	if string(out) != `<body>?OTR:[redacted].</body>` {
+		t.Errorf("Redact = %s", out)
+	}
+}
+
+func TestRedactLeavesPlainStanzasAlone(t *testing.T) {
+	in := []byte(`<message><body>hello</body></message>`)
+
+	if out := Redact(in); string(out) != string(in) {
+		t.Errorf("Redact changed a stanza with nothing to redact: %s", out)
+	}
+}
+
+func TestOpenUnknownScheme(t *testing.T) {
+	if _, err := Open("carrier-pigeon:///dev/null"); err == nil {
+		t.Errorf("expected an error for an unrecognized scheme")
+	}
+}
+
+func TestOpenRingDefaultCapacity(t *testing.T) {
+	sink, err := Open("ring:///")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer sink.Close()
+
+	rb, ok := sink.(*RingBuffer)
+	if !ok {
+		t.Fatalf("Open(\"ring:///\") returned %T, want *RingBuffer", sink)
+	}
+	if len(rb.entries) != defaultRingCapacity {
+		t.Errorf("capacity = %d, want %d", len(rb.entries), defaultRingCapacity)
+	}
+}
+
+func TestOpenRingExplicitCapacity(t *testing.T) {
+	sink, err := Open("ring:///?capacity=3")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer sink.Close()
+
+	rb := sink.(*RingBuffer)
+	if len(rb.entries) != 3 {
+		t.Errorf("capacity = %d, want 3", len(rb.entries))
+	}
+}
+
+func TestOpenRingInvalidCapacity(t *testing.T) {
+	if _, err := Open("ring:///?capacity=not-a-number"); err == nil {
+		t.Errorf("expected an error for a non-numeric capacity")
+	}
+}